@@ -0,0 +1,403 @@
+// Command proxies downloads free proxy lists, validates each candidate by
+// actually dialing through it, and optionally serves the working set as a
+// rotating proxy pool.
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/ogpourya/proxies/pkg/check"
+	"github.com/ogpourya/proxies/pkg/parse"
+	"github.com/ogpourya/proxies/pkg/pool"
+	"github.com/ogpourya/proxies/pkg/source"
+)
+
+// CLI flags controlling the validation stage.
+var (
+	flagCheckers        = flag.Int("checkers", 50, "number of concurrent proxy checker workers")
+	flagIPCheckerURL    = flag.String("ip-checker-url", "https://api.ipify.org", "URL used to confirm a proxy actually forwards traffic")
+	flagConnectTimeout  = flag.Duration("connect-timeout", 10*time.Second, "per-proxy dial/check timeout")
+	flagDedupIgnoreAuth = flag.Bool("dedup-ignore-auth", false, "dedupe by scheme://host:port, ignoring embedded credentials")
+	flagConfig          = flag.String("config", "", "YAML/JSON file describing proxy sources; if empty, use the built-in list")
+)
+
+// CLI flags controlling --serve mode, the embedded rotating proxy server.
+var (
+	flagServe              = flag.String("serve", "", "listen address (e.g. :8080) for an HTTP CONNECT + SOCKS5 server that dispenses validated proxies; disabled if empty")
+	flagAdminAddr          = flag.String("admin-addr", ":8081", "listen address for the /stats and /proxies.json observability endpoints in --serve mode")
+	flagBypassDomains      = flag.String("bypass-domains", "", "comma-separated domains (or @file, one per line) to dial direct instead of through the pool")
+	flagRevalidateInterval = flag.Duration("revalidate-interval", 5*time.Minute, "how often --serve mode rechecks pooled proxies in the background")
+	flagFailThreshold      = flag.Int("fail-threshold", 3, "consecutive check/dial failures before a proxy is evicted from the pool")
+)
+
+// collectCandidates downloads every source concurrently, normalizes each
+// line with parse.Parse, and dedupes the result. If progress is non-nil, it
+// receives each source's FetchResult as it completes.
+func collectCandidates(ctx context.Context, srcs []source.Source, progress chan<- source.FetchResult) []parse.Proxy {
+	client := &http.Client{Timeout: 15 * time.Second}
+
+	seen := make(map[string]struct{})
+	out := make([]parse.Proxy, 0, 1000)
+
+	for res := range source.FetchAll(ctx, srcs, client) {
+		if progress != nil {
+			progress <- res
+		}
+		if res.Err != nil {
+			continue // just skip this source
+		}
+		for _, raw := range res.Lines {
+			p, err := parse.Parse(raw, res.Source.ProtocolHint())
+			if err != nil {
+				continue
+			}
+			key := parse.DedupKey(p, *flagDedupIgnoreAuth)
+			if _, exists := seen[key]; exists {
+				continue
+			}
+			seen[key] = struct{}{}
+			out = append(out, p)
+		}
+	}
+
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	r.Shuffle(len(out), func(i, j int) { out[i], out[j] = out[j], out[i] })
+	return out
+}
+
+// refreshSourceLoop re-fetches s on its own RefreshInterval, validates what
+// it returns, and merges the working proxies into pool, for config sources
+// whose feed changes more often than the --serve default revalidation pass.
+func refreshSourceLoop(ctx context.Context, proxyPool *pool.Pool, s source.Source, localIP string) {
+	ticker := time.NewTicker(s.RefreshInterval())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			candidates := collectCandidates(ctx, []source.Source{s}, nil)
+			cfg := check.Config{Checkers: *flagCheckers, IPCheckerURL: *flagIPCheckerURL, Timeout: *flagConnectTimeout}
+			results := check.ValidateAll(ctx, candidates, cfg, localIP, nil)
+			proxyPool.Add(results)
+		}
+	}
+}
+
+// saveToFile writes the working proxies to proxies.txt in current dir.
+func saveToFile(results []check.Result) error {
+	f, err := os.Create("proxies.txt")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	for _, r := range results {
+		if !r.OK {
+			continue
+		}
+		_, _ = w.WriteString(r.Proxy.String() + "\n")
+	}
+	return w.Flush()
+}
+
+// saveJSON writes every working check record to proxies.json in current
+// dir, so callers can inspect latency/exit_ip without shelling out.
+func saveJSON(results []check.Result) error {
+	f, err := os.Create("proxies.json")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	working := make([]check.Result, 0, len(results))
+	for _, r := range results {
+		if r.OK {
+			working = append(working, r)
+		}
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(working)
+}
+
+// Bubble Tea UI
+type uiPhase int
+
+const (
+	phaseFetching uiPhase = iota
+	phaseValidating
+	phaseDone
+)
+
+type model struct {
+	spinner       spinner.Model
+	phase         uiPhase
+	status        string
+	totalSrc      int
+	fetchedSrc    int
+	srcs          []source.Source
+	srcProgressCh chan source.FetchResult
+
+	candidates int
+	checked    int
+	good       int
+	bad        int
+
+	finalGood int
+	err       error
+
+	progressCh chan check.Result
+	results    []check.Result
+
+	// resultsOut, if set, receives the final check results once validation
+	// finishes, so main can hand them to --serve mode without a tea.Program
+	// API that returns the final model.
+	resultsOut *[]check.Result
+}
+
+type sourcesFetchedMsg struct {
+	candidates []parse.Proxy
+	localIP    string
+	err        error
+}
+
+type sourceProgressMsg source.FetchResult
+
+type validationProgressMsg check.Result
+
+type validationDoneMsg struct {
+	results []check.Result
+	err     error
+}
+
+func initialModel(srcs []source.Source, resultsOut *[]check.Result) model {
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+	return model{
+		spinner:       s,
+		phase:         phaseFetching,
+		status:        "Downloading sources...",
+		totalSrc:      len(srcs),
+		srcs:          srcs,
+		srcProgressCh: make(chan source.FetchResult, 16),
+		resultsOut:    resultsOut,
+	}
+}
+
+func fetchSourcesCmd(srcs []source.Source, progressCh chan source.FetchResult) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+		defer cancel()
+
+		candidates := collectCandidates(ctx, srcs, progressCh)
+		close(progressCh)
+		localIP, err := check.FetchLocalIP(ctx, *flagIPCheckerURL, *flagConnectTimeout)
+		if err != nil {
+			return sourcesFetchedMsg{err: fmt.Errorf("determine local egress IP: %w", err)}
+		}
+		return sourcesFetchedMsg{candidates: candidates, localIP: localIP}
+	}
+}
+
+// waitForSourceProgress turns the next value received on ch into a tea.Msg.
+func waitForSourceProgress(ch chan source.FetchResult) tea.Cmd {
+	return func() tea.Msg {
+		r, ok := <-ch
+		if !ok {
+			return nil
+		}
+		return sourceProgressMsg(r)
+	}
+}
+
+// waitForProgress turns the next value received on ch into a tea.Msg.
+func waitForProgress(ch chan check.Result) tea.Cmd {
+	return func() tea.Msg {
+		r, ok := <-ch
+		if !ok {
+			return nil
+		}
+		return validationProgressMsg(r)
+	}
+}
+
+func validateCmd(candidates []parse.Proxy, localIP string, progressCh chan check.Result) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+		cfg := check.Config{Checkers: *flagCheckers, IPCheckerURL: *flagIPCheckerURL, Timeout: *flagConnectTimeout}
+		results := check.ValidateAll(ctx, candidates, cfg, localIP, progressCh)
+		close(progressCh)
+
+		var firstErr error
+		if len(candidates) == 0 {
+			firstErr = errors.New("no proxies collected (all sources failed or filtered)")
+		} else if err := saveToFile(results); err != nil {
+			firstErr = err
+		} else if err := saveJSON(results); err != nil {
+			firstErr = err
+		}
+		return validationDoneMsg{results: results, err: firstErr}
+	}
+}
+
+func (m model) Init() tea.Cmd {
+	return tea.Batch(
+		m.spinner.Tick,
+		fetchSourcesCmd(m.srcs, m.srcProgressCh),
+		waitForSourceProgress(m.srcProgressCh),
+	)
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case sourceProgressMsg:
+		m.fetchedSrc++
+		return m, waitForSourceProgress(m.srcProgressCh)
+	case sourcesFetchedMsg:
+		if msg.err != nil {
+			m.phase = phaseDone
+			m.err = msg.err
+			return m, tea.Quit
+		}
+		m.phase = phaseValidating
+		m.candidates = len(msg.candidates)
+		m.status = "Validating proxies..."
+		m.progressCh = make(chan check.Result, 16)
+		return m, tea.Batch(
+			validateCmd(msg.candidates, msg.localIP, m.progressCh),
+			waitForProgress(m.progressCh),
+		)
+	case validationProgressMsg:
+		m.checked++
+		if msg.OK {
+			m.good++
+		} else {
+			m.bad++
+		}
+		return m, waitForProgress(m.progressCh)
+	case validationDoneMsg:
+		m.phase = phaseDone
+		m.results = msg.results
+		m.finalGood = m.good
+		m.err = msg.err
+		if m.resultsOut != nil {
+			*m.resultsOut = msg.results
+		}
+		if m.err != nil {
+			m.status = "Finished with error"
+		} else {
+			m.status = "All done"
+		}
+		return m, tea.Quit
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	default:
+		return m, nil
+	}
+}
+
+func (m model) View() string {
+	if m.phase == phaseDone {
+		if m.err != nil {
+			return fmt.Sprintf("\n%s\n\nerror: %v\n\n", m.status, m.err)
+		}
+		return fmt.Sprintf("\n%s\nValidated %d/%d proxies, %d working -> saved to %s and %s\n\n",
+			m.status, m.checked, m.candidates, m.finalGood, "proxies.txt", "proxies.json")
+	}
+	if m.phase == phaseValidating {
+		return fmt.Sprintf("\n%s Sources downloaded %d/%d\nValidating proxies %d/%d (good: %d, bad: %d)...\n",
+			m.spinner.View(), m.totalSrc, m.totalSrc, m.checked, m.candidates, m.good, m.bad)
+	}
+	return fmt.Sprintf("\n%s %s\n\nSources downloaded %d/%d...\n", m.spinner.View(), m.status, m.fetchedSrc, m.totalSrc)
+}
+
+func main() {
+	flag.Parse()
+
+	if *flagCheckers <= 0 {
+		fmt.Printf("--checkers must be positive, got %d\n", *flagCheckers)
+		os.Exit(1)
+	}
+
+	srcs, err := source.Load(*flagConfig)
+	if err != nil {
+		fmt.Printf("failed to load --config: %v\n", err)
+		os.Exit(1)
+	}
+
+	var results []check.Result
+	p := tea.NewProgram(initialModel(srcs, &results))
+	if err := p.Start(); err != nil {
+		fmt.Printf("failed: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println()
+	fmt.Println("proxies saved to ./proxies.txt (working list) and ./proxies.json (with latency/exit_ip)")
+	fmt.Println("You can open it with: cat proxies.txt")
+
+	if *flagServe == "" {
+		return
+	}
+	working := 0
+	for _, r := range results {
+		if r.OK {
+			working++
+		}
+	}
+	if working == 0 {
+		fmt.Println("no working proxies collected; not starting --serve")
+		os.Exit(1)
+	}
+
+	bypass, err := pool.ParseBypassDomains(*flagBypassDomains)
+	if err != nil {
+		fmt.Printf("failed to parse --bypass-domains: %v\n", err)
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+	localIP, err := check.FetchLocalIP(ctx, *flagIPCheckerURL, *flagConnectTimeout)
+	if err != nil {
+		fmt.Printf("failed to determine local egress IP: %v\n", err)
+		os.Exit(1)
+	}
+	proxyPool := pool.New(results, localIP, *flagIPCheckerURL, *flagConnectTimeout, *flagFailThreshold, *flagDedupIgnoreAuth)
+	go proxyPool.Revalidate(ctx, *flagRevalidateInterval)
+
+	for _, s := range srcs {
+		if s.RefreshInterval() > 0 {
+			go refreshSourceLoop(ctx, proxyPool, s, localIP)
+		}
+	}
+
+	server := &pool.Server{Pool: proxyPool, Bypass: bypass, Timeout: *flagConnectTimeout}
+
+	if *flagAdminAddr != "" {
+		go func() {
+			if err := server.ServeAdmin(*flagAdminAddr); err != nil {
+				fmt.Printf("admin server stopped: %v\n", err)
+			}
+		}()
+	}
+
+	fmt.Printf("serving rotating proxy pool on %s (admin on %s)\n", *flagServe, *flagAdminAddr)
+	if err := server.ServeHTTPAndSOCKS5(ctx, *flagServe); err != nil {
+		fmt.Printf("serve failed: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,223 @@
+// Package check validates candidate proxies by actually dialing through
+// them, via a bounded worker pool.
+package check
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/net/proxy"
+
+	"github.com/ogpourya/proxies/pkg/parse"
+)
+
+// Config controls how candidate proxies are validated.
+type Config struct {
+	Checkers     int
+	IPCheckerURL string
+	Timeout      time.Duration
+}
+
+// Result is the outcome of probing a single candidate proxy.
+type Result struct {
+	Proxy       parse.Proxy `json:"proxy"`
+	Protocol    string      `json:"protocol"`
+	LatencyMs   int64       `json:"latency_ms"`
+	ExitIP      string      `json:"exit_ip"`
+	LastChecked time.Time   `json:"last_checked"`
+	OK          bool        `json:"-"`
+}
+
+// FetchLocalIP asks ipCheckerURL for our egress IP without going through a
+// proxy, so validated proxies can be confirmed to actually change the exit
+// address.
+func FetchLocalIP(ctx context.Context, ipCheckerURL string, timeout time.Duration) (string, error) {
+	client := &http.Client{Timeout: timeout}
+	return fetchIP(ctx, client, ipCheckerURL)
+}
+
+func fetchIP(ctx context.Context, client *http.Client, checkURL string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", checkURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("bad status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 256))
+	if err != nil {
+		return "", err
+	}
+	ip := strings.TrimSpace(string(body))
+	if net.ParseIP(ip) == nil {
+		return "", fmt.Errorf("non-IP response body %q", ip)
+	}
+	return ip, nil
+}
+
+// checkHTTP issues a GET for checkURL through an http/https proxy and
+// returns the exit IP reported by checkURL.
+func checkHTTP(ctx context.Context, p parse.Proxy, checkURL string, timeout time.Duration) (string, error) {
+	u, err := p.URL()
+	if err != nil {
+		return "", err
+	}
+	transport := &http.Transport{Proxy: http.ProxyURL(u)}
+	client := &http.Client{Transport: transport, Timeout: timeout}
+	return fetchIP(ctx, client, checkURL)
+}
+
+// checkSocks tunnels a GET for checkURL through a socks4/socks5 proxy via
+// golang.org/x/net/proxy and returns the exit IP reported by checkURL.
+//
+// x/net/proxy has no SOCKS4 dialer, so socks4 candidates are dialed with the
+// SOCKS5 client too; most free SOCKS4 listeners in the wild also speak enough
+// of the SOCKS5 handshake for a plain CONNECT to succeed.
+func checkSocks(ctx context.Context, p parse.Proxy, checkURL string, timeout time.Duration) (string, error) {
+	var auth *proxy.Auth
+	if p.HasAuth() {
+		auth = &proxy.Auth{User: p.User, Password: p.Pass}
+	}
+	hostPort := net.JoinHostPort(p.Host, strconv.Itoa(p.Port))
+	dialer, err := proxy.SOCKS5("tcp", hostPort, auth, proxy.Direct)
+	if err != nil {
+		return "", err
+	}
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return dialer.Dial(network, addr)
+		},
+	}
+	client := &http.Client{Transport: transport, Timeout: timeout}
+	return fetchIP(ctx, client, checkURL)
+}
+
+// checkSSH opens an SSH client connection to p, uses it to tunnel a GET for
+// checkURL, and returns the exit IP reported by checkURL. As with the other
+// checkers, a response whose body is not an IP address counts as a failure.
+func checkSSH(ctx context.Context, p parse.Proxy, checkURL string, timeout time.Duration) (string, error) {
+	config := &ssh.ClientConfig{
+		User:            p.User,
+		Auth:            []ssh.AuthMethod{ssh.Password(p.Pass)},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		Timeout:         timeout,
+	}
+	hostPort := net.JoinHostPort(p.Host, strconv.Itoa(p.Port))
+	client, err := ssh.Dial("tcp", hostPort, config)
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+
+	addr, err := checkURLAddr(checkURL)
+	if err != nil {
+		return "", err
+	}
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return client.Dial(network, addr)
+		},
+	}
+	httpClient := &http.Client{Transport: transport, Timeout: timeout}
+	return fetchIP(ctx, httpClient, checkURL)
+}
+
+// checkURLAddr returns checkURL's host:port, filling in the scheme's default
+// port when checkURL doesn't specify one.
+func checkURLAddr(checkURL string) (string, error) {
+	u, err := url.Parse(checkURL)
+	if err != nil {
+		return "", err
+	}
+	if u.Port() != "" {
+		return u.Host, nil
+	}
+	if u.Scheme == "https" {
+		return net.JoinHostPort(u.Hostname(), "443"), nil
+	}
+	return net.JoinHostPort(u.Hostname(), "80"), nil
+}
+
+// CheckProxy dials p through its own scheme's checker and reports whether it
+// actually forwarded traffic to a different exit IP than ours.
+func CheckProxy(ctx context.Context, p parse.Proxy, localIP, checkURL string, timeout time.Duration) Result {
+	start := time.Now()
+
+	var exitIP string
+	var err error
+	switch p.Scheme {
+	case "http", "https":
+		exitIP, err = checkHTTP(ctx, p, checkURL, timeout)
+	case "socks4", "socks5":
+		exitIP, err = checkSocks(ctx, p, checkURL, timeout)
+	case "ssh":
+		exitIP, err = checkSSH(ctx, p, checkURL, timeout)
+	default:
+		err = fmt.Errorf("unsupported protocol %q", p.Scheme)
+	}
+
+	res := Result{
+		Proxy:       p,
+		Protocol:    p.Scheme,
+		LatencyMs:   time.Since(start).Milliseconds(),
+		ExitIP:      exitIP,
+		LastChecked: time.Now(),
+	}
+	res.OK = err == nil && exitIP != "" && exitIP != localIP
+	return res
+}
+
+// ValidateAll spins up a bounded worker pool that checks every candidate
+// proxy and reports progress on progress as each check completes.
+func ValidateAll(ctx context.Context, candidates []parse.Proxy, cfg Config, localIP string, progress chan<- Result) []Result {
+	jobs := make(chan parse.Proxy)
+	results := make(chan Result)
+
+	var wg sync.WaitGroup
+	for i := 0; i < cfg.Checkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for candidate := range jobs {
+				cctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+				results <- CheckProxy(cctx, candidate, localIP, cfg.IPCheckerURL, cfg.Timeout)
+				cancel()
+			}
+		}()
+	}
+
+	go func() {
+		for _, c := range candidates {
+			jobs <- c
+		}
+		close(jobs)
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	out := make([]Result, 0, len(candidates))
+	for r := range results {
+		out = append(out, r)
+		if progress != nil {
+			progress <- r
+		}
+	}
+	return out
+}
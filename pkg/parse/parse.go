@@ -0,0 +1,139 @@
+// Package parse normalizes raw proxy list lines into a typed Proxy.
+package parse
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ValidProto lists the proxy schemes this module understands; DefaultPort
+// gives the port assumed when a line doesn't specify one.
+var (
+	ValidProto = map[string]bool{
+		"http":   true,
+		"https":  true,
+		"socks4": true,
+		"socks5": true,
+		"ssh":    true,
+	}
+
+	DefaultPort = map[string]int{
+		"http":   80,
+		"https":  443,
+		"socks4": 1080,
+		"socks5": 1080,
+		"ssh":    22,
+	}
+)
+
+// Proxy is a normalized proxy endpoint, with credentials split out so
+// callers don't need to re-parse Proxy.String().
+type Proxy struct {
+	Scheme string
+	Host   string
+	Port   int
+	User   string
+	Pass   string
+}
+
+// HasAuth reports whether the proxy carries embedded credentials.
+func (p Proxy) HasAuth() bool {
+	return p.User != ""
+}
+
+// String renders p back to "scheme://[user[:pass]@]host:port".
+func (p Proxy) String() string {
+	hostPort := net.JoinHostPort(p.Host, strconv.Itoa(p.Port))
+	switch {
+	case p.User == "":
+		return fmt.Sprintf("%s://%s", p.Scheme, hostPort)
+	case p.Pass == "":
+		return fmt.Sprintf("%s://%s@%s", p.Scheme, p.User, hostPort)
+	default:
+		return fmt.Sprintf("%s://%s:%s@%s", p.Scheme, p.User, p.Pass, hostPort)
+	}
+}
+
+// MarshalJSON encodes p as its canonical URI string, so downstream consumers
+// of proxies.json don't need to know about the Proxy struct at all.
+func (p Proxy) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.String())
+}
+
+// URL returns p as a *url.URL, for callers that want to plug it straight
+// into http.ProxyURL or similar.
+func (p Proxy) URL() (*url.URL, error) {
+	return url.Parse(p.String())
+}
+
+// Parse normalizes raw into a Proxy using net/url, so userinfo and bracketed
+// IPv6 hosts are handled the same way the standard library handles any other
+// URI. hintProto supplies the scheme when raw has none (e.g. a bare
+// "host:port" line pulled from a source known to be SOCKS5).
+func Parse(raw, hintProto string) (Proxy, error) {
+	line := strings.TrimSpace(raw)
+	if line == "" {
+		return Proxy{}, fmt.Errorf("empty line")
+	}
+	if strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+		return Proxy{}, fmt.Errorf("comment line")
+	}
+
+	if !strings.Contains(line, "://") {
+		p := hintProto
+		if p == "" {
+			p = "http"
+		}
+		line = p + "://" + line
+	}
+
+	u, err := url.Parse(line)
+	if err != nil {
+		return Proxy{}, err
+	}
+	scheme := strings.ToLower(u.Scheme)
+	if !ValidProto[scheme] {
+		return Proxy{}, fmt.Errorf("unsupported scheme %q", scheme)
+	}
+
+	host := u.Hostname()
+	if host == "" {
+		return Proxy{}, fmt.Errorf("missing host in %q", raw)
+	}
+	portStr := u.Port()
+	if portStr == "" {
+		def, ok := DefaultPort[scheme]
+		if !ok {
+			return Proxy{}, fmt.Errorf("no default port for scheme %q", scheme)
+		}
+		portStr = strconv.Itoa(def)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port < 1 || port > 65535 {
+		return Proxy{}, fmt.Errorf("invalid port in %q", raw)
+	}
+
+	p := Proxy{Scheme: scheme, Host: host, Port: port}
+	if u.User != nil {
+		p.User = u.User.Username()
+		p.Pass, _ = u.User.Password()
+	}
+	return p, nil
+}
+
+// DedupKey returns the map key callers should dedupe proxies on. With
+// ignoreAuth it drops embedded credentials from the key, so the same
+// endpoint offered with two different logins collapses to one entry;
+// otherwise two different credential sets for the same host:port are kept
+// as distinct proxies.
+func DedupKey(p Proxy, ignoreAuth bool) string {
+	host := strings.ToLower(p.Host)
+	if ignoreAuth {
+		return fmt.Sprintf("%s://%s:%d", p.Scheme, host, p.Port)
+	}
+	return fmt.Sprintf("%s://%s:%s@%s:%d", p.Scheme, p.User, p.Pass, host, p.Port)
+}
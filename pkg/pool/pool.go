@@ -0,0 +1,561 @@
+// Package pool keeps validated proxies in memory and dispenses them to
+// clients of an embedded HTTP CONNECT + SOCKS5 server, modeled on a
+// dispensing/loadbalancer engine rather than a one-shot checker.
+package pool
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/net/proxy"
+
+	"github.com/ogpourya/proxies/pkg/check"
+	"github.com/ogpourya/proxies/pkg/parse"
+)
+
+// entry tracks one validated proxy's live health inside a Pool.
+type entry struct {
+	result           check.Result
+	consecutiveFails int
+	alive            bool
+}
+
+// Pool keeps the validated proxies in memory and dispenses them to clients
+// round-robin, per protocol, evicting proxies that fail too many times in a
+// row and periodically rechecking every entry, evicted ones included.
+type Pool struct {
+	mu              sync.Mutex
+	all             []*entry
+	byScheme        map[string][]*entry
+	next            map[string]int
+	seen            map[string]struct{}
+	localIP         string
+	checkURL        string
+	timeout         time.Duration
+	failThreshold   int
+	dedupIgnoreAuth bool
+}
+
+// New builds a Pool from already-validated results.
+func New(results []check.Result, localIP, checkURL string, timeout time.Duration, failThreshold int, dedupIgnoreAuth bool) *Pool {
+	p := &Pool{
+		byScheme:        make(map[string][]*entry),
+		next:            make(map[string]int),
+		seen:            make(map[string]struct{}),
+		localIP:         localIP,
+		checkURL:        checkURL,
+		timeout:         timeout,
+		failThreshold:   failThreshold,
+		dedupIgnoreAuth: dedupIgnoreAuth,
+	}
+	p.Add(results)
+	return p
+}
+
+// Add merges newly-validated results into the pool, for config sources with
+// a refresh_interval that re-fetch and revalidate on their own schedule. A
+// result whose parse.DedupKey already exists in the pool is skipped, so a
+// re-fetched still-alive proxy doesn't pile up as a duplicate *entry.
+func (p *Pool) Add(results []check.Result) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, r := range results {
+		if !r.OK {
+			continue
+		}
+		key := parse.DedupKey(r.Proxy, p.dedupIgnoreAuth)
+		if _, exists := p.seen[key]; exists {
+			continue
+		}
+		p.seen[key] = struct{}{}
+		e := &entry{result: r, alive: true}
+		p.all = append(p.all, e)
+		p.byScheme[r.Protocol] = append(p.byScheme[r.Protocol], e)
+	}
+}
+
+// get returns the next alive entry preferring protoHint's sub-pool (e.g. a
+// SOCKS5 client gets a socks4/socks5 upstream); it falls back to any alive
+// entry when the hint's sub-pool is empty or protoHint is "".
+func (p *Pool) get(protoHint string) (*entry, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if e := p.pickFrom(p.byScheme[protoHint], protoHint); e != nil {
+		return e, nil
+	}
+	if protoHint == "socks5" {
+		if e := p.pickFrom(p.byScheme["socks4"], "socks4"); e != nil {
+			return e, nil
+		}
+	}
+	if e := p.pickFrom(p.all, "*"); e != nil {
+		return e, nil
+	}
+	return nil, errors.New("proxy pool is empty")
+}
+
+func (p *Pool) pickFrom(candidates []*entry, key string) *entry {
+	n := len(candidates)
+	if n == 0 {
+		return nil
+	}
+	start := p.next[key]
+	for i := 0; i < n; i++ {
+		idx := (start + i) % n
+		if candidates[idx].alive {
+			p.next[key] = idx + 1
+			return candidates[idx]
+		}
+	}
+	return nil
+}
+
+// put reports the outcome of dispensing e, evicting it from the pool after
+// failThreshold consecutive failures.
+func (p *Pool) put(e *entry, success bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if success {
+		e.consecutiveFails = 0
+		return
+	}
+	e.consecutiveFails++
+	if e.consecutiveFails >= p.failThreshold {
+		e.alive = false
+	}
+}
+
+// Stats summarizes pool health for the /stats admin endpoint.
+type Stats struct {
+	Total        int            `json:"total"`
+	Alive        int            `json:"alive"`
+	Dead         int            `json:"dead"`
+	AvgLatencyMs float64        `json:"avg_latency_ms"`
+	TopByLatency []check.Result `json:"top_by_latency"`
+}
+
+// Stats returns a snapshot of pool health, including the topN proxies by
+// latency.
+func (p *Pool) Stats(topN int) Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := Stats{Total: len(p.all)}
+	var latencySum int64
+	alive := make([]check.Result, 0, len(p.all))
+	for _, e := range p.all {
+		if e.alive {
+			stats.Alive++
+			latencySum += e.result.LatencyMs
+			alive = append(alive, e.result)
+		} else {
+			stats.Dead++
+		}
+	}
+	if stats.Alive > 0 {
+		stats.AvgLatencyMs = float64(latencySum) / float64(stats.Alive)
+	}
+	sort.Slice(alive, func(i, j int) bool { return alive[i].LatencyMs < alive[j].LatencyMs })
+	if topN < len(alive) {
+		alive = alive[:topN]
+	}
+	stats.TopByLatency = alive
+	return stats
+}
+
+// Proxies returns the current check results for every alive pool entry, for
+// the /proxies.json admin endpoint.
+func (p *Pool) Proxies() []check.Result {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make([]check.Result, 0, len(p.all))
+	for _, e := range p.all {
+		if e.alive {
+			out = append(out, e.result)
+		}
+	}
+	return out
+}
+
+// Revalidate periodically re-checks every pool entry and updates its health,
+// running until ctx is cancelled.
+func (p *Pool) Revalidate(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.mu.Lock()
+			entries := append([]*entry(nil), p.all...)
+			p.mu.Unlock()
+			for _, e := range entries {
+				cctx, cancel := context.WithTimeout(ctx, p.timeout)
+				res := check.CheckProxy(cctx, e.result.Proxy, p.localIP, p.checkURL, p.timeout)
+				cancel()
+				p.mu.Lock()
+				e.result = res
+				if res.OK {
+					e.consecutiveFails = 0
+					e.alive = true
+				} else {
+					e.consecutiveFails++
+					if e.consecutiveFails >= p.failThreshold {
+						e.alive = false
+					}
+				}
+				p.mu.Unlock()
+			}
+		}
+	}
+}
+
+// BypassList is the set of domains a Server routes direct instead of
+// through the pool.
+type BypassList struct {
+	domains []string
+}
+
+// ParseBypassDomains parses spec, which is either a comma-separated domain
+// list or "@path/to/file" (one domain per line).
+func ParseBypassDomains(spec string) (*BypassList, error) {
+	if spec == "" {
+		return &BypassList{}, nil
+	}
+	var raw []string
+	if strings.HasPrefix(spec, "@") {
+		data, err := os.ReadFile(strings.TrimPrefix(spec, "@"))
+		if err != nil {
+			return nil, err
+		}
+		raw = strings.Split(string(data), "\n")
+	} else {
+		raw = strings.Split(spec, ",")
+	}
+	b := &BypassList{}
+	for _, d := range raw {
+		d = strings.ToLower(strings.TrimSpace(d))
+		if d == "" || strings.HasPrefix(d, "#") {
+			continue
+		}
+		b.domains = append(b.domains, d)
+	}
+	return b, nil
+}
+
+// Matches reports whether host (or a subdomain of it) is in the bypass list.
+func (b *BypassList) Matches(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, d := range b.domains {
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+// sshTunnelConn closes its backing ssh.Client alongside the tunnelled conn,
+// since the conn is only usable while the client stays open.
+type sshTunnelConn struct {
+	net.Conn
+	client *ssh.Client
+}
+
+func (c *sshTunnelConn) Close() error {
+	connErr := c.Conn.Close()
+	c.client.Close()
+	return connErr
+}
+
+// dialThroughProxy opens network/addr through upstream, using whichever
+// client upstream's own protocol requires.
+func dialThroughProxy(ctx context.Context, upstream parse.Proxy, network, addr string, timeout time.Duration) (net.Conn, error) {
+	switch upstream.Scheme {
+	case "http", "https":
+		return dialThroughHTTPConnect(ctx, upstream, addr, timeout)
+	case "socks4", "socks5":
+		return dialThroughSocks(upstream, network, addr)
+	case "ssh":
+		return dialThroughSSH(upstream, network, addr, timeout)
+	default:
+		return nil, fmt.Errorf("unsupported upstream protocol %q", upstream.Scheme)
+	}
+}
+
+func dialThroughHTTPConnect(ctx context.Context, upstream parse.Proxy, addr string, timeout time.Duration) (net.Conn, error) {
+	hostPort := net.JoinHostPort(upstream.Host, strconv.Itoa(upstream.Port))
+	d := net.Dialer{Timeout: timeout}
+	conn, err := d.DialContext(ctx, "tcp", hostPort)
+	if err != nil {
+		return nil, err
+	}
+	req := &http.Request{
+		Method: "CONNECT",
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if upstream.HasAuth() {
+		req.Header.Set("Proxy-Authorization", "Basic "+basicAuth(upstream.User, upstream.Pass))
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("upstream CONNECT failed: %s", resp.Status)
+	}
+	return conn, nil
+}
+
+func basicAuth(user, pass string) string {
+	return base64.StdEncoding.EncodeToString([]byte(user + ":" + pass))
+}
+
+func dialThroughSocks(upstream parse.Proxy, network, addr string) (net.Conn, error) {
+	var auth *proxy.Auth
+	if upstream.HasAuth() {
+		auth = &proxy.Auth{User: upstream.User, Password: upstream.Pass}
+	}
+	hostPort := net.JoinHostPort(upstream.Host, strconv.Itoa(upstream.Port))
+	dialer, err := proxy.SOCKS5("tcp", hostPort, auth, proxy.Direct)
+	if err != nil {
+		return nil, err
+	}
+	return dialer.Dial(network, addr)
+}
+
+func dialThroughSSH(upstream parse.Proxy, network, addr string, timeout time.Duration) (net.Conn, error) {
+	config := &ssh.ClientConfig{
+		User:            upstream.User,
+		Auth:            []ssh.AuthMethod{ssh.Password(upstream.Pass)},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		Timeout:         timeout,
+	}
+	hostPort := net.JoinHostPort(upstream.Host, strconv.Itoa(upstream.Port))
+	client, err := ssh.Dial("tcp", hostPort, config)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := client.Dial(network, addr)
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+	return &sshTunnelConn{Conn: conn, client: client}, nil
+}
+
+// pipe relays bytes between two established connections until either side
+// closes, used once a CONNECT/SOCKS5 tunnel has been established.
+func pipe(a, b net.Conn) {
+	done := make(chan struct{}, 2)
+	go func() { io.Copy(a, b); done <- struct{}{} }()
+	go func() { io.Copy(b, a); done <- struct{}{} }()
+	<-done
+	a.Close()
+	b.Close()
+}
+
+// dispense resolves the upstream for network/addr, dialing direct if host is
+// bypassed, and reports the outcome back to the pool before returning conn.
+func (p *Pool) dispense(ctx context.Context, bypass *BypassList, protoHint, network, addr string, timeout time.Duration) (net.Conn, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err == nil && bypass.Matches(host) {
+		d := net.Dialer{Timeout: timeout}
+		return d.DialContext(ctx, network, addr)
+	}
+
+	e, err := p.get(protoHint)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := dialThroughProxy(ctx, e.result.Proxy, network, addr, timeout)
+	p.put(e, err == nil)
+	if err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
+// Server runs the --serve listener and, optionally, the /stats and
+// /proxies.json admin endpoints.
+type Server struct {
+	Pool    *Pool
+	Bypass  *BypassList
+	Timeout time.Duration
+}
+
+// ServeHTTPAndSOCKS5 accepts clients on addr and dispatches them to the HTTP
+// or SOCKS5 handler based on the first byte they send.
+func (s *Server) ServeHTTPAndSOCKS5(ctx context.Context, addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer ln.Close()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+		go s.handleConn(ctx, conn)
+	}
+}
+
+func (s *Server) handleConn(ctx context.Context, conn net.Conn) {
+	br := bufio.NewReader(conn)
+	first, err := br.Peek(1)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	if first[0] == 0x05 {
+		s.handleSOCKS5Client(ctx, conn, br)
+	} else {
+		s.handleHTTPClient(ctx, conn, br)
+	}
+}
+
+// handleHTTPClient services a client that speaks plain HTTP proxying or
+// HTTP CONNECT.
+func (s *Server) handleHTTPClient(ctx context.Context, conn net.Conn, br *bufio.Reader) {
+	defer conn.Close()
+	req, err := http.ReadRequest(br)
+	if err != nil {
+		return
+	}
+
+	if req.Method == http.MethodConnect {
+		upstream, err := s.Pool.dispense(ctx, s.Bypass, "", "tcp", req.Host, s.Timeout)
+		if err != nil {
+			fmt.Fprintf(conn, "HTTP/1.1 502 Bad Gateway\r\n\r\n%v", err)
+			return
+		}
+		fmt.Fprint(conn, "HTTP/1.1 200 Connection Established\r\n\r\n")
+		pipe(conn, upstream)
+		return
+	}
+
+	addr := req.Host
+	if !strings.Contains(addr, ":") {
+		addr = net.JoinHostPort(addr, "80")
+	}
+	upstream, err := s.Pool.dispense(ctx, s.Bypass, "", "tcp", addr, s.Timeout)
+	if err != nil {
+		fmt.Fprintf(conn, "HTTP/1.1 502 Bad Gateway\r\n\r\n%v", err)
+		return
+	}
+	defer upstream.Close()
+	if err := req.WriteProxy(upstream); err != nil {
+		return
+	}
+	io.Copy(conn, upstream)
+}
+
+// handleSOCKS5Client services a minimal no-auth SOCKS5 CONNECT client,
+// preferring a socks4/socks5 upstream from the pool.
+func (s *Server) handleSOCKS5Client(ctx context.Context, conn net.Conn, br *bufio.Reader) {
+	defer conn.Close()
+
+	hdr := make([]byte, 2)
+	if _, err := io.ReadFull(br, hdr); err != nil || hdr[0] != 0x05 {
+		return
+	}
+	methods := make([]byte, hdr[1])
+	if _, err := io.ReadFull(br, methods); err != nil {
+		return
+	}
+	if _, err := conn.Write([]byte{0x05, 0x00}); err != nil { // no auth required
+		return
+	}
+
+	reqHdr := make([]byte, 4)
+	if _, err := io.ReadFull(br, reqHdr); err != nil || reqHdr[1] != 0x01 { // CONNECT only
+		conn.Write([]byte{0x05, 0x07, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
+		return
+	}
+
+	var host string
+	switch reqHdr[3] {
+	case 0x01: // IPv4
+		ip := make([]byte, 4)
+		if _, err := io.ReadFull(br, ip); err != nil {
+			return
+		}
+		host = net.IP(ip).String()
+	case 0x03: // domain name
+		l := make([]byte, 1)
+		if _, err := io.ReadFull(br, l); err != nil {
+			return
+		}
+		name := make([]byte, l[0])
+		if _, err := io.ReadFull(br, name); err != nil {
+			return
+		}
+		host = string(name)
+	case 0x04: // IPv6
+		ip := make([]byte, 16)
+		if _, err := io.ReadFull(br, ip); err != nil {
+			return
+		}
+		host = net.IP(ip).String()
+	default:
+		return
+	}
+	portBuf := make([]byte, 2)
+	if _, err := io.ReadFull(br, portBuf); err != nil {
+		return
+	}
+	port := int(portBuf[0])<<8 | int(portBuf[1])
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+
+	upstream, err := s.Pool.dispense(ctx, s.Bypass, "socks5", "tcp", addr, s.Timeout)
+	if err != nil {
+		conn.Write([]byte{0x05, 0x01, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	conn.Write([]byte{0x05, 0x00, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
+	pipe(conn, upstream)
+}
+
+// ServeAdmin exposes /stats and /proxies.json for observability while the
+// pool server is running.
+func (s *Server) ServeAdmin(addr string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/stats", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, s.Pool.Stats(10))
+	})
+	mux.HandleFunc("/proxies.json", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, s.Pool.Proxies())
+	})
+	return http.ListenAndServe(addr, mux)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
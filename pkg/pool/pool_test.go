@@ -0,0 +1,98 @@
+package pool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ogpourya/proxies/pkg/check"
+	"github.com/ogpourya/proxies/pkg/parse"
+)
+
+func newTestResult(host string) check.Result {
+	return check.Result{
+		Proxy:    parse.Proxy{Scheme: "http", Host: host, Port: 8080},
+		Protocol: "http",
+		OK:       true,
+	}
+}
+
+func TestPoolRoundRobin(t *testing.T) {
+	results := []check.Result{newTestResult("a"), newTestResult("b"), newTestResult("c")}
+	p := New(results, "9.9.9.9", "http://check", time.Second, 3, false)
+
+	var got []string
+	for i := 0; i < len(results); i++ {
+		e, err := p.get("")
+		if err != nil {
+			t.Fatalf("get() returned error: %v", err)
+		}
+		got = append(got, e.result.Proxy.Host)
+	}
+
+	want := []string{"a", "b", "c"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("round-robin order = %v, want %v", got, want)
+		}
+	}
+
+	// It wraps back around to the first entry.
+	e, err := p.get("")
+	if err != nil {
+		t.Fatalf("get() returned error: %v", err)
+	}
+	if e.result.Proxy.Host != "a" {
+		t.Fatalf("get() after a full cycle = %q, want %q", e.result.Proxy.Host, "a")
+	}
+}
+
+func TestPoolEvictsAfterFailThreshold(t *testing.T) {
+	results := []check.Result{newTestResult("a"), newTestResult("b")}
+	p := New(results, "9.9.9.9", "http://check", time.Second, 2, false)
+
+	e, err := p.get("")
+	if err != nil {
+		t.Fatalf("get() returned error: %v", err)
+	}
+	if e.result.Proxy.Host != "a" {
+		t.Fatalf("expected entry %q first, got %q", "a", e.result.Proxy.Host)
+	}
+
+	p.put(e, false)
+	if !e.alive {
+		t.Fatalf("entry should still be alive after 1 of 2 allowed failures")
+	}
+	p.put(e, false)
+	if e.alive {
+		t.Fatalf("entry should be evicted after reaching the fail threshold")
+	}
+
+	// Both remaining gets should now return "b", since "a" is dead.
+	for i := 0; i < 2; i++ {
+		got, err := p.get("")
+		if err != nil {
+			t.Fatalf("get() returned error: %v", err)
+		}
+		if got.result.Proxy.Host != "b" {
+			t.Fatalf("get() after eviction = %q, want %q", got.result.Proxy.Host, "b")
+		}
+	}
+}
+
+func TestPoolPutSuccessResetsFailCount(t *testing.T) {
+	results := []check.Result{newTestResult("a")}
+	p := New(results, "9.9.9.9", "http://check", time.Second, 2, false)
+
+	e, err := p.get("")
+	if err != nil {
+		t.Fatalf("get() returned error: %v", err)
+	}
+	p.put(e, false)
+	p.put(e, true)
+	if e.consecutiveFails != 0 {
+		t.Fatalf("consecutiveFails = %d after a success, want 0", e.consecutiveFails)
+	}
+	if !e.alive {
+		t.Fatalf("entry should remain alive")
+	}
+}
@@ -0,0 +1,285 @@
+package source
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// AuthConfig describes how a configured source authenticates its requests.
+type AuthConfig struct {
+	Type   string `yaml:"type" json:"type"` // "basic", "bearer", or "header"
+	User   string `yaml:"user,omitempty" json:"user,omitempty"`
+	Pass   string `yaml:"pass,omitempty" json:"pass,omitempty"`
+	Token  string `yaml:"token,omitempty" json:"token,omitempty"`
+	Header string `yaml:"header,omitempty" json:"header,omitempty"`
+	Value  string `yaml:"value,omitempty" json:"value,omitempty"`
+}
+
+// SourceConfig describes one entry in a --config file.
+type SourceConfig struct {
+	URL             string        `yaml:"url" json:"url"`
+	ProtocolHint    string        `yaml:"protocol_hint" json:"protocol_hint"`
+	Enabled         *bool         `yaml:"enabled" json:"enabled"`
+	Auth            *AuthConfig   `yaml:"auth" json:"auth"`
+	Timeout         time.Duration `yaml:"timeout" json:"timeout"`
+	RefreshInterval time.Duration `yaml:"refresh_interval" json:"refresh_interval"`
+
+	// Format is "lines" (default, the current plaintext behavior), "json",
+	// or "csv".
+	Format string `yaml:"format" json:"format"`
+
+	// JSONArrayPath is the dotted field path to the array of proxy records
+	// when format is "json"; empty means the response body is itself that
+	// array. JSONProxyField names the field in each record holding the proxy
+	// string ("proxy" if unset).
+	JSONArrayPath  string `yaml:"json_array_path" json:"json_array_path"`
+	JSONProxyField string `yaml:"json_proxy_field" json:"json_proxy_field"`
+
+	// CSV column indexes (0-based) when format is "csv". CSVHostCol and
+	// CSVPortCol are required for that format, so they're *int rather than
+	// int: a nil pointer means "not set in the config" and is rejected by
+	// FromConfig, instead of silently defaulting to column 0. CSVProtoCol is
+	// genuinely optional; nil means the source has no protocol column.
+	CSVHostCol  *int `yaml:"csv_host_col" json:"csv_host_col"`
+	CSVPortCol  *int `yaml:"csv_port_col" json:"csv_port_col"`
+	CSVProtoCol *int `yaml:"csv_proto_col" json:"csv_proto_col"`
+}
+
+// FileConfig is the top-level shape of a --config file.
+type FileConfig struct {
+	Sources []SourceConfig `yaml:"sources" json:"sources"`
+}
+
+// LoadConfig reads and parses a --config file; format (YAML or JSON) is
+// chosen from the file extension, defaulting to YAML.
+func LoadConfig(path string) (*FileConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg FileConfig
+	if strings.HasSuffix(strings.ToLower(path), ".json") {
+		err = json.Unmarshal(data, &cfg)
+	} else {
+		err = yaml.Unmarshal(data, &cfg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// Load returns the sources described by a --config file at path, or the
+// built-in Default list when path is empty.
+func Load(path string) ([]Source, error) {
+	if path == "" {
+		return Default(), nil
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	return FromConfig(cfg)
+}
+
+// FromConfig builds Sources from a parsed FileConfig, skipping any entry
+// with enabled: false.
+func FromConfig(cfg *FileConfig) ([]Source, error) {
+	out := make([]Source, 0, len(cfg.Sources))
+	for _, sc := range cfg.Sources {
+		if sc.Enabled != nil && !*sc.Enabled {
+			continue
+		}
+		switch sc.Format {
+		case "", "lines":
+			out = append(out, configuredLinesSource{sc})
+		case "json":
+			out = append(out, configuredJSONSource{sc})
+		case "csv":
+			if sc.CSVHostCol == nil || sc.CSVPortCol == nil {
+				return nil, fmt.Errorf("source %q: format csv requires csv_host_col and csv_port_col", sc.URL)
+			}
+			out = append(out, configuredCSVSource{sc})
+		default:
+			return nil, fmt.Errorf("source %q: unknown format %q", sc.URL, sc.Format)
+		}
+	}
+	return out, nil
+}
+
+// applyAuth adds the credentials described by auth to req, if any.
+func applyAuth(req *http.Request, auth *AuthConfig) {
+	if auth == nil {
+		return
+	}
+	switch auth.Type {
+	case "basic":
+		req.SetBasicAuth(auth.User, auth.Pass)
+	case "bearer":
+		req.Header.Set("Authorization", "Bearer "+auth.Token)
+	case "header":
+		req.Header.Set(auth.Header, auth.Value)
+	}
+}
+
+// fetchBody downloads cfg.URL, applying its auth and a per-source timeout
+// override (if set) on top of client.
+func fetchBody(ctx context.Context, client *http.Client, cfg SourceConfig) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", cfg.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "proxies-go/1.0")
+	applyAuth(req, cfg.Auth)
+
+	c := client
+	if cfg.Timeout > 0 {
+		c2 := *client
+		c2.Timeout = cfg.Timeout
+		c = &c2
+	}
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("bad status %d", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// configuredLinesSource is a --config entry with format: lines (the default
+// plaintext, one-proxy-per-line format).
+type configuredLinesSource struct{ cfg SourceConfig }
+
+func (s configuredLinesSource) Name() string                   { return s.cfg.URL }
+func (s configuredLinesSource) ProtocolHint() string           { return s.cfg.ProtocolHint }
+func (s configuredLinesSource) RefreshInterval() time.Duration { return s.cfg.RefreshInterval }
+
+func (s configuredLinesSource) Fetch(ctx context.Context, client *http.Client) ([]string, error) {
+	body, err := fetchBody(ctx, client, s.cfg)
+	if err != nil {
+		return nil, err
+	}
+	return splitLines(body), nil
+}
+
+func splitLines(body []byte) []string {
+	var lines []string
+	for _, l := range strings.Split(string(body), "\n") {
+		lines = append(lines, l)
+	}
+	return lines
+}
+
+// configuredJSONSource is a --config entry with format: json, for APIs like
+// ProxyScrape that return an array of proxy records.
+type configuredJSONSource struct{ cfg SourceConfig }
+
+func (s configuredJSONSource) Name() string                   { return s.cfg.URL }
+func (s configuredJSONSource) ProtocolHint() string           { return s.cfg.ProtocolHint }
+func (s configuredJSONSource) RefreshInterval() time.Duration { return s.cfg.RefreshInterval }
+
+func (s configuredJSONSource) Fetch(ctx context.Context, client *http.Client) ([]string, error) {
+	body, err := fetchBody(ctx, client, s.cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var root any
+	if err := json.Unmarshal(body, &root); err != nil {
+		return nil, err
+	}
+	arr, err := navigateJSONArray(root, s.cfg.JSONArrayPath)
+	if err != nil {
+		return nil, err
+	}
+
+	field := s.cfg.JSONProxyField
+	if field == "" {
+		field = "proxy"
+	}
+	var lines []string
+	for _, item := range arr {
+		obj, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		if v, ok := obj[field].(string); ok {
+			lines = append(lines, v)
+		}
+	}
+	return lines, nil
+}
+
+func navigateJSONArray(root any, path string) ([]any, error) {
+	cur := root
+	if path != "" {
+		for _, part := range strings.Split(path, ".") {
+			m, ok := cur.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("json_array_path %q: %q is not an object", path, part)
+			}
+			cur, ok = m[part]
+			if !ok {
+				return nil, fmt.Errorf("json_array_path %q: field %q not found", path, part)
+			}
+		}
+	}
+	arr, ok := cur.([]any)
+	if !ok {
+		return nil, fmt.Errorf("json_array_path %q does not point to an array", path)
+	}
+	return arr, nil
+}
+
+// configuredCSVSource is a --config entry with format: csv.
+type configuredCSVSource struct{ cfg SourceConfig }
+
+func (s configuredCSVSource) Name() string                   { return s.cfg.URL }
+func (s configuredCSVSource) ProtocolHint() string           { return s.cfg.ProtocolHint }
+func (s configuredCSVSource) RefreshInterval() time.Duration { return s.cfg.RefreshInterval }
+
+func (s configuredCSVSource) Fetch(ctx context.Context, client *http.Client) ([]string, error) {
+	body, err := fetchBody(ctx, client, s.cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := csv.NewReader(bytes.NewReader(body)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	hostCol, portCol := *s.cfg.CSVHostCol, *s.cfg.CSVPortCol
+	var lines []string
+	for _, rec := range records {
+		if hostCol < 0 || hostCol >= len(rec) || portCol < 0 || portCol >= len(rec) {
+			continue
+		}
+		host := strings.TrimSpace(rec[hostCol])
+		port := strings.TrimSpace(rec[portCol])
+		if host == "" || port == "" {
+			continue
+		}
+		if s.cfg.CSVProtoCol != nil && *s.cfg.CSVProtoCol >= 0 && *s.cfg.CSVProtoCol < len(rec) {
+			proto := strings.TrimSpace(rec[*s.cfg.CSVProtoCol])
+			lines = append(lines, fmt.Sprintf("%s://%s:%s", proto, host, port))
+		} else {
+			lines = append(lines, fmt.Sprintf("%s:%s", host, port))
+		}
+	}
+	return lines, nil
+}
@@ -0,0 +1,144 @@
+// Package source fetches raw candidate proxy lines from configurable lists.
+package source
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Source fetches raw candidate proxy lines from somewhere - a plaintext
+// list, a private feed, anything a caller wants to register.
+type Source interface {
+	// Name identifies the source for logging, typically its URL.
+	Name() string
+	// ProtocolHint is used by parse.Parse when a fetched line has no scheme.
+	ProtocolHint() string
+	// RefreshInterval is how often --serve mode should re-fetch this source
+	// and merge new proxies into the live pool; zero means never.
+	RefreshInterval() time.Duration
+	// Fetch returns the raw lines found at this source.
+	Fetch(ctx context.Context, client *http.Client) ([]string, error)
+}
+
+// URLSource fetches a plaintext, one-proxy-per-line list over HTTP(S). It
+// never refreshes on a schedule; use a configured source for that.
+type URLSource struct {
+	URL  string
+	Hint string
+}
+
+func (s URLSource) Name() string                   { return s.URL }
+func (s URLSource) ProtocolHint() string           { return s.Hint }
+func (s URLSource) RefreshInterval() time.Duration { return 0 }
+
+func (s URLSource) Fetch(ctx context.Context, client *http.Client) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", s.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "proxies-go/1.0")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("bad status %d", resp.StatusCode)
+	}
+
+	sc := bufio.NewScanner(resp.Body)
+	var lines []string
+	for sc.Scan() {
+		lines = append(lines, sc.Text())
+	}
+	return lines, nil
+}
+
+// hintFromURL guesses a URLSource's protocol hint from the source URL's own
+// naming convention (e.g. ".../socks5.txt").
+func hintFromURL(u string) string {
+	lu := strings.ToLower(u)
+	switch {
+	case strings.Contains(lu, "ssh"):
+		return "ssh"
+	case strings.Contains(lu, "socks5"):
+		return "socks5"
+	case strings.Contains(lu, "socks4"):
+		return "socks4"
+	case strings.Contains(lu, "/https") || strings.Contains(lu, "https.txt"):
+		return "https"
+	case strings.Contains(lu, "/http") || strings.Contains(lu, "http.txt"):
+		return "http"
+	default:
+		return ""
+	}
+}
+
+// defaultURLs are the free-proxy-list sources this tool ships with.
+var defaultURLs = []string{
+	"https://raw.githubusercontent.com/TheSpeedX/SOCKS-List/master/socks5.txt",
+	"https://raw.githubusercontent.com/TheSpeedX/SOCKS-List/master/socks4.txt",
+	"https://raw.githubusercontent.com/TheSpeedX/SOCKS-List/master/http.txt",
+	"https://cdn.jsdelivr.net/gh/proxifly/free-proxy-list@main/proxies/all/data.txt",
+	"https://github.com/monosans/proxy-list/raw/refs/heads/main/proxies/all.txt",
+	"https://github.com/mmpx12/proxy-list/raw/refs/heads/master/proxies.txt",
+	"https://github.com/zloi-user/hideip.me/raw/refs/heads/master/http.txt",
+	"https://github.com/zloi-user/hideip.me/raw/refs/heads/master/https.txt",
+	"https://github.com/zloi-user/hideip.me/raw/refs/heads/master/socks4.txt",
+	"https://github.com/zloi-user/hideip.me/raw/refs/heads/master/socks5.txt",
+	"https://github.com/iplocate/free-proxy-list/raw/refs/heads/main/all-proxies.txt",
+	"https://github.com/Zaeem20/FREE_PROXIES_LIST/raw/refs/heads/master/http.txt",
+	"https://github.com/Zaeem20/FREE_PROXIES_LIST/raw/refs/heads/master/https.txt",
+	"https://github.com/Zaeem20/FREE_PROXIES_LIST/raw/refs/heads/master/socks4.txt",
+	"https://raw.githubusercontent.com/ALIILAPRO/Proxy/main/http.txt",
+	"https://raw.githubusercontent.com/ALIILAPRO/Proxy/main/socks4.txt",
+	"https://raw.githubusercontent.com/ALIILAPRO/Proxy/main/socks5.txt",
+	"https://github.com/rdavydov/proxy-list/raw/refs/heads/main/proxies/http.txt",
+	"https://github.com/rdavydov/proxy-list/raw/refs/heads/main/proxies/socks4.txt",
+	"https://github.com/rdavydov/proxy-list/raw/refs/heads/main/proxies/socks5.txt",
+	"https://github.com/ShiftyTR/Proxy-List/raw/refs/heads/master/proxy.txt",
+	"https://github.com/Vann-Dev/proxy-list/raw/refs/heads/main/proxies/http.txt",
+	"https://github.com/Vann-Dev/proxy-list/raw/refs/heads/main/proxies/https.txt",
+}
+
+// Default returns the built-in sources this tool ships with.
+func Default() []Source {
+	out := make([]Source, 0, len(defaultURLs))
+	for _, u := range defaultURLs {
+		out = append(out, URLSource{URL: u, Hint: hintFromURL(u)})
+	}
+	return out
+}
+
+// FetchResult pairs a Source with what it returned, so callers can report
+// per-source failures instead of only an aggregate count.
+type FetchResult struct {
+	Source Source
+	Lines  []string
+	Err    error
+}
+
+// FetchAll fetches every source concurrently and streams results on the
+// returned channel, closing it once all sources have reported in.
+func FetchAll(ctx context.Context, srcs []Source, client *http.Client) <-chan FetchResult {
+	ch := make(chan FetchResult)
+	var wg sync.WaitGroup
+	for _, s := range srcs {
+		wg.Add(1)
+		go func(s Source) {
+			defer wg.Done()
+			lines, err := s.Fetch(ctx, client)
+			ch <- FetchResult{Source: s, Lines: lines, Err: err}
+		}(s)
+	}
+	go func() {
+		wg.Wait()
+		close(ch)
+	}()
+	return ch
+}